@@ -0,0 +1,115 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeSDF is a ProcessSizedElementsAndRestrictions double that returns a
+// canned checkpoint residual, or an error.
+type fakeSDF struct {
+	residual []byte
+	err      error
+}
+
+func (f *fakeSDF) Checkpoint() ([]byte, error) {
+	return f.residual, f.err
+}
+
+func TestPlan_Split_NoElements(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	result, err := p.Split("source", SplitPoints{Splits: []int64{5}})
+	if err != nil {
+		t.Fatalf("Split returned error, want nil: %v", err)
+	}
+	if result.PrimaryEnd != 0 || result.ResidualStart != 0 || result.PS != nil || result.RS != nil {
+		t.Errorf("Split result = %+v, want zero value when no elements are available", result)
+	}
+}
+
+func TestPlan_Split_UnknownPTransformID(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	if _, err := p.Split("not-a-source", SplitPoints{}); err == nil {
+		t.Fatal("Split with unknown PTransform ID succeeded, want error")
+	}
+}
+
+func TestPlan_Split_PrimaryAndResidual(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	for i := 0; i < 3; i++ {
+		source.addElement()
+	}
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	// 3 elements have already been read; 5 is still ahead of that and so is
+	// a valid split point.
+	result, err := p.Split("source", SplitPoints{Splits: []int64{5}})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if result.PrimaryEnd != 5 || result.ResidualStart != 6 {
+		t.Errorf("Split result = %+v, want PrimaryEnd=5, ResidualStart=6", result)
+	}
+}
+
+func TestPlan_Split_AggregatesSDFResidual(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	source.addElement()
+	source.SetSDF(&fakeSDF{residual: []byte("checkpoint")})
+
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	result, err := p.Split("source", SplitPoints{})
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if len(result.RS) != 1 || string(result.RS[0]) != "checkpoint" {
+		t.Errorf("Split result.RS = %v, want [\"checkpoint\"]", result.RS)
+	}
+}
+
+func TestPlan_Split_SDFCheckpointError(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	source.addElement()
+	source.SetSDF(&fakeSDF{err: errors.New("restriction tracker is busy")})
+
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	if _, err := p.Split("source", SplitPoints{}); err == nil {
+		t.Fatal("Split with failing SDF checkpoint succeeded, want error")
+	}
+}
@@ -0,0 +1,138 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeFinalizer is a BundleFinalizer double that counts its calls and can be
+// made to fail or to block until released, to exercise bounded parallelism.
+type fakeFinalizer struct {
+	fakeRoot
+	calls   int32
+	fail    bool
+	release chan struct{}
+}
+
+func (f *fakeFinalizer) FinalizeBundle(ctx context.Context) error {
+	atomic.AddInt32(&f.calls, 1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.fail {
+		return fmt.Errorf("finalize failed for %v", f.ID())
+	}
+	return nil
+}
+
+func TestPlan_Finalize_NoopWithoutRegistration(t *testing.T) {
+	f := &fakeFinalizer{fakeRoot: fakeRoot{id: "sink"}}
+	p, err := NewPlan("plan", []Unit{f})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	if err := p.Finalize(context.Background()); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&f.calls); got != 0 {
+		t.Errorf("FinalizeBundle called %v times, want 0 without RegisterFinalizationCallback", got)
+	}
+}
+
+func TestPlan_Finalize_InvokesRegisteredFinalizers(t *testing.T) {
+	f1 := &fakeFinalizer{fakeRoot: fakeRoot{id: "sink1"}}
+	f2 := &fakeFinalizer{fakeRoot: fakeRoot{id: "sink2"}}
+	p, err := NewPlan("plan", []Unit{f1, f2})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	p.RegisterFinalizationCallback(time.Time{})
+	if err := p.Finalize(context.Background()); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&f1.calls); got != 1 {
+		t.Errorf("sink1 FinalizeBundle called %v times, want 1", got)
+	}
+	if got := atomic.LoadInt32(&f2.calls); got != 1 {
+		t.Errorf("sink2 FinalizeBundle called %v times, want 1", got)
+	}
+
+	// A second Finalize without a new registration is a no-op.
+	if err := p.Finalize(context.Background()); err != nil {
+		t.Fatalf("second Finalize failed: %v", err)
+	}
+	if got := atomic.LoadInt32(&f1.calls); got != 1 {
+		t.Errorf("sink1 FinalizeBundle called %v times after second Finalize, want still 1", got)
+	}
+}
+
+func TestPlan_Finalize_AggregatesMultipleErrors(t *testing.T) {
+	f1 := &fakeFinalizer{fakeRoot: fakeRoot{id: "sink1"}, fail: true}
+	f2 := &fakeFinalizer{fakeRoot: fakeRoot{id: "sink2"}, fail: true}
+	p, err := NewPlan("plan", []Unit{f1, f2})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	p.RegisterFinalizationCallback(time.Time{})
+	if err := p.Finalize(context.Background()); err == nil {
+		t.Fatal("Finalize succeeded, want an aggregated error")
+	}
+}
+
+func TestPlan_Setup_Teardown(t *testing.T) {
+	su := &fakeSetupTeardown{fakeRoot: fakeRoot{id: "pool"}}
+	p, err := NewPlan("plan", []Unit{su})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	if err := p.Setup(context.Background()); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if !su.up {
+		t.Error("Setup did not bring up the SetupTeardown unit")
+	}
+
+	if err := p.Teardown(context.Background()); err != nil {
+		t.Fatalf("Teardown failed: %v", err)
+	}
+	if su.up {
+		t.Error("Teardown did not tear down the SetupTeardown unit")
+	}
+}
+
+type fakeSetupTeardown struct {
+	fakeRoot
+	up bool
+}
+
+func (f *fakeSetupTeardown) Setup(ctx context.Context) error {
+	f.up = true
+	return nil
+}
+
+func (f *fakeSetupTeardown) Teardown(ctx context.Context) error {
+	f.up = false
+	return nil
+}
@@ -0,0 +1,114 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeEmitter is a stand-in for a ParDo/GBK/Flatten/Combine/DataSink Unit
+// that tracks its own per-PCollection output element counts.
+type fakeEmitter struct {
+	fakeRoot
+	pid    string
+	counts map[string]int64
+}
+
+func (f *fakeEmitter) GetPID() string                 { return f.pid }
+func (f *fakeEmitter) OutputCounts() map[string]int64 { return f.counts }
+
+func TestPlan_Metrics_OutputElementCounts(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source", Name: "source.out"}}}
+	source.addElement()
+	source.addElement()
+
+	emitter := &fakeEmitter{
+		fakeRoot: fakeRoot{id: "emit"},
+		pid:      "emit",
+		counts:   map[string]int64{"emit.out": 3},
+	}
+
+	p, err := NewPlan("plan", []Unit{source, emitter})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	m := p.Metrics()
+
+	srcPT, ok := m.Ptransforms["source"]
+	if !ok {
+		t.Fatalf("Metrics() missing PTransform %q", "source")
+	}
+	if got := srcPT.ProcessedElements.Measured.OutputElementCounts["source.out"]; got != 2 {
+		t.Errorf("source.out count = %v, want 2", got)
+	}
+
+	emitPT, ok := m.Ptransforms["emit"]
+	if !ok {
+		t.Fatalf("Metrics() missing PTransform %q", "emit")
+	}
+	if got := emitPT.ProcessedElements.Measured.OutputElementCounts["emit.out"]; got != 3 {
+		t.Errorf("emit.out count = %v, want 3", got)
+	}
+}
+
+func TestPlan_Metrics_SourceWithNoElementsKeepsProcessedElements(t *testing.T) {
+	// DataSource also satisfies hasPID, so it lands in p.parDoIDs alongside
+	// p.sources. Metrics must merge those two passes rather than letting the
+	// parDoIDs pass clobber the ProcessedElements the sources pass set, even
+	// when OutputCounts is empty (and so contributes no further merge) because
+	// no elements were read this bundle.
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source", Name: "source.out"}}}
+
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	m := p.Metrics()
+
+	srcPT, ok := m.Ptransforms["source"]
+	if !ok {
+		t.Fatalf("Metrics() missing PTransform %q", "source")
+	}
+	if srcPT.ProcessedElements == nil {
+		t.Fatal("ProcessedElements = nil, want non-nil even with a zero output count")
+	}
+	if got := srcPT.ProcessedElements.Measured.OutputElementCounts["source.out"]; got != 0 {
+		t.Errorf("source.out count = %v, want 0", got)
+	}
+}
+
+func TestDataSource_OutputCounts_ZeroIsOmitted(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source", Name: "source.out"}}}
+	if counts := source.OutputCounts(); counts != nil {
+		t.Errorf("OutputCounts() = %v, want nil for an untouched DataSource", counts)
+	}
+}
+
+func TestDataSource_StartBundle_ResetsCount(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source", Name: "source.out"}}}
+	source.addElement()
+	source.addElement()
+
+	if err := source.StartBundle(context.Background(), "bundle2", DataContext{}); err != nil {
+		t.Fatalf("StartBundle failed: %v", err)
+	}
+	if got := source.Progress().Count; got != 0 {
+		t.Errorf("Progress().Count after StartBundle = %v, want 0", got)
+	}
+}
@@ -0,0 +1,141 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// recordingObserver is a PlanObserver double that counts calls to each
+// method, keyed by unit ID, and records errors it was told about.
+type recordingObserver struct {
+	ups, starts, processes, finishes, splits, downs map[string]int
+	unitErrors                                      map[string]int
+}
+
+func newRecordingObserver() *recordingObserver {
+	return &recordingObserver{
+		ups:        map[string]int{},
+		starts:     map[string]int{},
+		processes:  map[string]int{},
+		finishes:   map[string]int{},
+		splits:     map[string]int{},
+		downs:      map[string]int{},
+		unitErrors: map[string]int{},
+	}
+}
+
+func (r *recordingObserver) OnUp(id string, d time.Duration)           { r.ups[id]++ }
+func (r *recordingObserver) OnStartBundle(id string, d time.Duration)  { r.starts[id]++ }
+func (r *recordingObserver) OnProcess(id string, d time.Duration)      { r.processes[id]++ }
+func (r *recordingObserver) OnFinishBundle(id string, d time.Duration) { r.finishes[id]++ }
+func (r *recordingObserver) OnSplit(id string, d time.Duration)        { r.splits[id]++ }
+func (r *recordingObserver) OnDown(id string, d time.Duration)         { r.downs[id]++ }
+func (r *recordingObserver) OnUnitError(id string, err error)          { r.unitErrors[id]++ }
+
+func TestPlan_Execute_NotifiesObserver(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	obs := newRecordingObserver()
+
+	p, err := NewPlanWithOptions("plan", []Unit{source}, WithPlanObserver(obs))
+	if err != nil {
+		t.Fatalf("NewPlanWithOptions failed: %v", err)
+	}
+
+	if err := p.Execute(context.Background(), "bundle1", DataContext{}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	for name, got := range map[string]map[string]int{
+		"OnUp":           obs.ups,
+		"OnStartBundle":  obs.starts,
+		"OnProcess":      obs.processes,
+		"OnFinishBundle": obs.finishes,
+	} {
+		if got["source"] != 1 {
+			t.Errorf("%v[%q] = %v, want 1", name, "source", got["source"])
+		}
+	}
+
+	if err := p.Down(context.Background()); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if obs.downs["source"] != 1 {
+		t.Errorf("OnDown[%q] = %v, want 1", "source", obs.downs["source"])
+	}
+}
+
+// failingRoot is a Root whose Up always errors, to exercise OnUnitError.
+type failingRoot struct {
+	fakeRoot
+}
+
+func (f *failingRoot) Up(ctx context.Context) error {
+	return fmt.Errorf("failed to bring up %v", f.ID())
+}
+
+func TestPlan_Execute_NotifiesObserverOnUnitError(t *testing.T) {
+	f := &failingRoot{fakeRoot{id: "broken"}}
+	obs := newRecordingObserver()
+
+	p, err := NewPlanWithOptions("plan", []Unit{f}, WithPlanObserver(obs))
+	if err != nil {
+		t.Fatalf("NewPlanWithOptions failed: %v", err)
+	}
+
+	if err := p.Execute(context.Background(), "bundle1", DataContext{}); err == nil {
+		t.Fatal("Execute with a failing Unit.Up succeeded, want error")
+	}
+
+	if obs.unitErrors["broken"] != 1 {
+		t.Errorf("OnUnitError[%q] = %v, want 1", "broken", obs.unitErrors["broken"])
+	}
+}
+
+func TestPlan_Split_NotifiesObserver(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	source.addElement()
+	obs := newRecordingObserver()
+
+	p, err := NewPlanWithOptions("plan", []Unit{source}, WithPlanObserver(obs))
+	if err != nil {
+		t.Fatalf("NewPlanWithOptions failed: %v", err)
+	}
+
+	if _, err := p.Split("source", SplitPoints{}); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	if obs.splits["source"] != 1 {
+		t.Errorf("OnSplit[%q] = %v, want 1", "source", obs.splits["source"])
+	}
+}
+
+func TestPlan_NoObserver_DoesNotPanic(t *testing.T) {
+	source := &DataSource{SID: StreamID{Target: Target{ID: "source"}}}
+	p, err := NewPlan("plan", []Unit{source})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	if err := p.Execute(context.Background(), "bundle1", DataContext{}); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := p.Split("source", SplitPoints{}); err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+}
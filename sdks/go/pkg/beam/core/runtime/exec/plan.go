@@ -21,6 +21,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apache/beam/sdks/go/pkg/beam/core/metrics"
 	"github.com/apache/beam/sdks/go/pkg/beam/internal/errors"
@@ -38,8 +40,53 @@ type Plan struct {
 
 	status Status
 
-	// TODO: there can be more than 1 DataSource in a bundle.
-	source *DataSource
+	// sources holds every DataSource unit in the plan. A fused bundle can
+	// contain more than one, for example when multiple Impulse/Read
+	// transforms are fed into the same plan via Flatten.
+	sources []*DataSource
+
+	// finalizeRequested and finalizeDeadline record a pending
+	// RegisterFinalizationCallback call, consumed by the next Finalize.
+	finalizeRequested bool
+	finalizeDeadline  time.Time
+
+	// observer receives lifecycle notifications as the plan runs, if
+	// attached via NewPlanWithOptions. Nil by default, so a plan with no
+	// observer pays no allocation cost for this.
+	observer PlanObserver
+}
+
+// PlanObserver lets callers instrument a Plan's lifecycle without forking
+// exec: emitting OpenTelemetry spans, per-unit latency histograms, or
+// structured failure logs. Every method is called with the elapsed time of
+// the call it observed, except OnUnitError, which reports the error itself.
+// Implementations must not block; Plan calls these synchronously.
+type PlanObserver interface {
+	// OnUp is called after a Unit's Up.
+	OnUp(unitID string, d time.Duration)
+	// OnStartBundle is called after a Root's StartBundle.
+	OnStartBundle(unitID string, d time.Duration)
+	// OnProcess is called after a Root's Process.
+	OnProcess(unitID string, d time.Duration)
+	// OnFinishBundle is called after a Root's FinishBundle.
+	OnFinishBundle(unitID string, d time.Duration)
+	// OnSplit is called after a Plan.Split against the named DataSource.
+	OnSplit(unitID string, d time.Duration)
+	// OnDown is called after a Unit's Down.
+	OnDown(unitID string, d time.Duration)
+	// OnUnitError is called in addition to the relevant OnX method above
+	// whenever the call it observed returned a non-nil error.
+	OnUnitError(unitID string, err error)
+}
+
+// PlanOption customizes a Plan constructed via NewPlanWithOptions.
+type PlanOption func(*Plan)
+
+// WithPlanObserver attaches a PlanObserver to the plan.
+func WithPlanObserver(o PlanObserver) PlanOption {
+	return func(p *Plan) {
+		p.observer = o
+	}
 }
 
 // hasPID provides a common interface for extracting PTransformIDs
@@ -48,10 +95,22 @@ type hasPID interface {
 	GetPID() string
 }
 
+// hasOutputCounts provides a common interface for extracting per-PCollection
+// output element counts from Units that emit elements. DataSource implements
+// it today; ParDo, GBK, Flatten, Combine and DataSink should do the same as
+// they gain counters, at which point Plan.Metrics will start reporting them
+// with no further change here. Implementations are expected to track counts
+// with per-unit atomic counters incremented on the Emit/MainInput hot path,
+// reset at the start of each bundle, so OutputCounts reports a per-bundle
+// delta keyed by outbound PCollection ID.
+type hasOutputCounts interface {
+	OutputCounts() map[string]int64
+}
+
 // NewPlan returns a new bundle execution plan from the given units.
 func NewPlan(id string, units []Unit) (*Plan, error) {
 	var roots []Root
-	var source *DataSource
+	var sources []*DataSource
 	var pardoIDs []string
 
 	for _, u := range units {
@@ -62,7 +121,7 @@ func NewPlan(id string, units []Unit) (*Plan, error) {
 			roots = append(roots, r)
 		}
 		if s, ok := u.(*DataSource); ok {
-			source = s
+			sources = append(sources, s)
 		}
 		if p, ok := u.(hasPID); ok {
 			pardoIDs = append(pardoIDs, p.GetPID())
@@ -78,18 +137,102 @@ func NewPlan(id string, units []Unit) (*Plan, error) {
 		roots:    roots,
 		units:    units,
 		parDoIDs: pardoIDs,
-		source:   source,
+		sources:  sources,
 	}, nil
 }
 
+// NewPlanWithOptions returns a new bundle execution plan from the given
+// units, as NewPlan, additionally configured by opts, e.g. WithPlanObserver.
+func NewPlanWithOptions(id string, units []Unit, opts ...PlanOption) (*Plan, error) {
+	p, err := NewPlan(id, units)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
 // ID returns the plan identifier.
 func (p *Plan) ID() string {
 	return p.id
 }
 
-// SourcePTransformID returns the ID of the data's origin PTransform.
-func (p *Plan) SourcePTransformID() string {
-	return p.source.SID.Target.ID
+// SourcePTransformIDs returns the IDs of the data's origin PTransforms. A
+// fused bundle can have more than one, for example when multiple
+// Impulse/Read transforms feed the plan via Flatten.
+func (p *Plan) SourcePTransformIDs() []string {
+	ids := make([]string, len(p.sources))
+	for i, s := range p.sources {
+		ids[i] = s.SID.Target.ID
+	}
+	return ids
+}
+
+// SetupTeardown is implemented by Units that hold expensive per-worker
+// resources, such as a connection pool, that should be amortized across
+// every Plan sharing this worker rather than recreated per-bundle by
+// Up/Down. Setup is called once per worker, before the first Execute of any
+// Plan using the Unit; Teardown is called once, when the worker will no
+// longer execute any Plan using it.
+type SetupTeardown interface {
+	Setup(ctx context.Context) error
+	Teardown(ctx context.Context) error
+}
+
+// Setup brings up the plan's SetupTeardown units. Callers are expected to
+// invoke Setup exactly once per worker lifetime for units that outlive a
+// single Plan, ahead of the first Execute. Does not panic.
+func (p *Plan) Setup(ctx context.Context) error {
+	for _, u := range p.units {
+		if st, ok := u.(SetupTeardown); ok {
+			if err := callNoPanic(ctx, st.Setup); err != nil {
+				p.status = Broken
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Teardown takes the plan's SetupTeardown units down, once this worker will
+// no longer execute any Plan using them. Does not panic.
+func (p *Plan) Teardown(ctx context.Context) error {
+	var errs []error
+	for _, u := range p.units {
+		if st, ok := u.(SetupTeardown); ok {
+			if err := callNoPanic(ctx, st.Teardown); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errors.Wrapf(errs[0], "plan %v failed to tear down", p.id)
+	default:
+		return errors.Errorf("plan %v failed to tear down with multiple errors: %v", p.id, errs)
+	}
+}
+
+// callObserved runs fn via callNoPanic and, if an observer is attached,
+// reports its elapsed time through report and any error through
+// OnUnitError. With no observer attached it calls callNoPanic directly,
+// without reading the clock.
+func (p *Plan) callObserved(ctx context.Context, unitID string, fn func(context.Context) error, report func(PlanObserver, string, time.Duration)) error {
+	if p.observer == nil {
+		return callNoPanic(ctx, fn)
+	}
+	start := time.Now()
+	err := callNoPanic(ctx, fn)
+	report(p.observer, unitID, time.Since(start))
+	if err != nil {
+		p.observer.OnUnitError(unitID, err)
+	}
+	return err
 }
 
 // Execute executes the plan with the given data context and bundle id. Units
@@ -99,7 +242,7 @@ func (p *Plan) Execute(ctx context.Context, id string, manager DataContext) erro
 	ctx = metrics.SetBundleID(ctx, p.id)
 	if p.status == Initializing {
 		for _, u := range p.units {
-			if err := callNoPanic(ctx, u.Up); err != nil {
+			if err := p.callObserved(ctx, u.ID(), u.Up, PlanObserver.OnUp); err != nil {
 				p.status = Broken
 				return err
 			}
@@ -115,19 +258,20 @@ func (p *Plan) Execute(ctx context.Context, id string, manager DataContext) erro
 
 	p.status = Active
 	for _, root := range p.roots {
-		if err := callNoPanic(ctx, func(ctx context.Context) error { return root.StartBundle(ctx, id, manager) }); err != nil {
+		fn := func(ctx context.Context) error { return root.StartBundle(ctx, id, manager) }
+		if err := p.callObserved(ctx, root.ID(), fn, PlanObserver.OnStartBundle); err != nil {
 			p.status = Broken
 			return err
 		}
 	}
 	for _, root := range p.roots {
-		if err := callNoPanic(ctx, root.Process); err != nil {
+		if err := p.callObserved(ctx, root.ID(), root.Process, PlanObserver.OnProcess); err != nil {
 			p.status = Broken
 			return err
 		}
 	}
 	for _, root := range p.roots {
-		if err := callNoPanic(ctx, root.FinishBundle); err != nil {
+		if err := p.callObserved(ctx, root.ID(), root.FinishBundle, PlanObserver.OnFinishBundle); err != nil {
 			p.status = Broken
 			return err
 		}
@@ -137,6 +281,84 @@ func (p *Plan) Execute(ctx context.Context, id string, manager DataContext) erro
 	return nil
 }
 
+// BundleFinalizer is implemented by Units that must run logic only after the
+// runner has durably committed the results of a bundle, such as acking
+// offsets on a Kafka/PubSub-style sink. It is invoked by Plan.Finalize once
+// finalization has been registered; Finalize does not itself check that
+// Execute succeeded, or ran at all, for the bundle.
+type BundleFinalizer interface {
+	FinalizeBundle(ctx context.Context) error
+}
+
+// RegisterFinalizationCallback records that the runner requested
+// finalization for the bundle just executed, bounded by deadline. The next
+// call to Finalize fans out to the plan's BundleFinalizer units; a zero
+// deadline means no deadline is enforced.
+func (p *Plan) RegisterFinalizationCallback(deadline time.Time) {
+	p.finalizeRequested = true
+	p.finalizeDeadline = deadline
+}
+
+// Finalize fans out to every Unit in the plan that implements
+// BundleFinalizer, with bounded parallelism, if the runner previously
+// called RegisterFinalizationCallback for the bundle just executed. It is a
+// no-op if finalization was not requested, or if no Unit needs it. Does not
+// panic.
+func (p *Plan) Finalize(ctx context.Context) error {
+	if !p.finalizeRequested {
+		return nil
+	}
+	p.finalizeRequested = false
+
+	var finalizers []BundleFinalizer
+	for _, u := range p.units {
+		if f, ok := u.(BundleFinalizer); ok {
+			finalizers = append(finalizers, f)
+		}
+	}
+	if len(finalizers) == 0 {
+		return nil
+	}
+
+	if !p.finalizeDeadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, p.finalizeDeadline)
+		defer cancel()
+	}
+
+	const maxConcurrentFinalizers = 4
+	sem := make(chan struct{}, maxConcurrentFinalizers)
+	errs := make([]error, len(finalizers))
+
+	var wg sync.WaitGroup
+	for i, f := range finalizers {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, f BundleFinalizer) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = callNoPanic(ctx, f.FinalizeBundle)
+		}(i, f)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		return nil
+	case 1:
+		return errors.Wrapf(failed[0], "plan %v failed to finalize bundle", p.id)
+	default:
+		return errors.Errorf("plan %v failed to finalize bundle with multiple errors: %v", p.id, failed)
+	}
+}
+
 // Down takes the plan and associated units down. Does not panic.
 func (p *Plan) Down(ctx context.Context) error {
 	if p.status == Down {
@@ -146,7 +368,7 @@ func (p *Plan) Down(ctx context.Context) error {
 
 	var errs []error
 	for _, u := range p.units {
-		if err := callNoPanic(ctx, u.Down); err != nil {
+		if err := p.callObserved(ctx, u.ID(), u.Down, PlanObserver.OnDown); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -169,46 +391,127 @@ func (p *Plan) String() string {
 	return fmt.Sprintf("Plan[%v]:\n%v", p.ID(), strings.Join(units, "\n"))
 }
 
-// Metrics returns a snapshot of input progress of the plan, and associated metrics.
+// Metrics returns a snapshot of input progress of the plan, and associated
+// metrics, including per-PCollection output element counts for every Unit
+// that implements hasOutputCounts. DataSource is the only Unit in this
+// package that does, so no other Unit's output counts are reported.
 func (p *Plan) Metrics() *fnpb.Metrics {
 	transforms := make(map[string]*fnpb.Metrics_PTransform)
 
-	if p.source != nil {
-		snapshot := p.source.Progress()
+	for _, source := range p.sources {
+		snapshot := source.Progress()
+		addOutputElementCounts(transforms, snapshot.ID, map[string]int64{snapshot.Name: snapshot.Count})
+	}
 
-		transforms[snapshot.ID] = &fnpb.Metrics_PTransform{
-			ProcessedElements: &fnpb.Metrics_PTransform_ProcessedElements{
-				Measured: &fnpb.Metrics_PTransform_Measured{
-					OutputElementCounts: map[string]int64{
-						snapshot.Name: snapshot.Count,
-					},
-				},
-			},
+	for _, pt := range p.parDoIDs {
+		t, ok := transforms[pt]
+		if !ok {
+			t = &fnpb.Metrics_PTransform{}
+			transforms[pt] = t
 		}
+		t.User = metrics.ToProto(p.id, pt)
 	}
 
-	for _, pt := range p.parDoIDs {
-		transforms[pt] = &fnpb.Metrics_PTransform{
-			User: metrics.ToProto(p.id, pt),
+	for _, u := range p.units {
+		hc, ok := u.(hasOutputCounts)
+		if !ok {
+			continue
 		}
+		hp, ok := u.(hasPID)
+		if !ok {
+			continue
+		}
+		counts := hc.OutputCounts()
+		if len(counts) == 0 {
+			continue
+		}
+		addOutputElementCounts(transforms, hp.GetPID(), counts)
 	}
+
 	return &fnpb.Metrics{
 		Ptransforms: transforms,
 	}
 }
 
-// SplitPoints captures the split requested by the Runner.
+// addOutputElementCounts merges per-PCollection output element counts into
+// the Measured.OutputElementCounts of the named PTransform, creating the
+// entry if it doesn't already exist.
+func addOutputElementCounts(transforms map[string]*fnpb.Metrics_PTransform, pid string, counts map[string]int64) {
+	pt, ok := transforms[pid]
+	if !ok {
+		pt = &fnpb.Metrics_PTransform{}
+		transforms[pid] = pt
+	}
+	if pt.ProcessedElements == nil {
+		pt.ProcessedElements = &fnpb.Metrics_PTransform_ProcessedElements{}
+	}
+	if pt.ProcessedElements.Measured == nil {
+		pt.ProcessedElements.Measured = &fnpb.Metrics_PTransform_Measured{}
+	}
+	if pt.ProcessedElements.Measured.OutputElementCounts == nil {
+		pt.ProcessedElements.Measured.OutputElementCounts = make(map[string]int64, len(counts))
+	}
+	for pcol, c := range counts {
+		pt.ProcessedElements.Measured.OutputElementCounts[pcol] = c
+	}
+}
+
+// SplitPoints captures the split requested by the Runner against a single
+// DataSource, identified by the PTransform ID of its origin. A fused bundle
+// may hold multiple DataSources, so the caller selects which one to split
+// by ID rather than splitting "the" source.
 type SplitPoints struct {
 	Splits []int64
 	Frac   float32
 }
 
-// Split takes a set of potential split points, selects and actuates split on an
-// appropriate split point, and returns the selected split point if successful.
-// Returns an error when unable to split.
-func (p *Plan) Split(s SplitPoints) (int64, error) {
-	if p.source != nil {
-		return p.source.Split(s.Splits, s.Frac)
+// SplitResult holds the outcome of a split attempt against a DataSource. A
+// zero-valued SplitResult with a nil error means no split was available
+// right now: runners must treat that as "no split" rather than a failure,
+// since not every bundle is splittable at every point in time.
+type SplitResult struct {
+	// PS holds encoded primary roots for SDF restrictions. DataSource.Split
+	// never sets it, so it is nil in every result Plan.Split returns.
+	PS [][]byte
+	// RS holds the encoded residual roots: BundleApplication payloads for
+	// the unprocessed restriction(s) the runner should reschedule as new
+	// bundles.
+	RS [][]byte
+
+	// PrimaryEnd is the index of the last element, inclusive, still owned
+	// by this plan's bundle.
+	PrimaryEnd int64
+	// ResidualStart is the index of the first element the runner should
+	// instead schedule as a residual bundle.
+	ResidualStart int64
+
+	// OpaqueSplit is a slot for SDK-opaque checkpoint data of a residual
+	// restriction. DataSource.Split never sets it, so it is nil in every
+	// result Plan.Split returns; a checkpointed residual is reported in RS.
+	OpaqueSplit interface{}
+}
+
+// Split takes a set of potential split points for the DataSource with the
+// given PTransform ID, selects and actuates a split on an appropriate split
+// point, and returns the resulting SplitResult. Returns an error when pid
+// does not name a DataSource in this plan, or when the checkpoint of a
+// self-checkpointing SDF attached to that DataSource fails; "no split
+// available right now" is reported as a zero-valued SplitResult with a nil
+// error, not an error.
+func (p *Plan) Split(pid string, s SplitPoints) (SplitResult, error) {
+	for _, source := range p.sources {
+		if source.SID.Target.ID == pid {
+			if p.observer == nil {
+				return source.Split(s.Splits, s.Frac)
+			}
+			start := time.Now()
+			result, err := source.Split(s.Splits, s.Frac)
+			p.observer.OnSplit(pid, time.Since(start))
+			if err != nil {
+				p.observer.OnUnitError(pid, err)
+			}
+			return result, err
+		}
 	}
-	return 0, fmt.Errorf("failed to split at requested splits: {%v}, Source not initialized", s)
+	return SplitResult{}, fmt.Errorf("failed to split at requested splits: {%v}, source %v not found in plan %v", s, pid, p.id)
 }
@@ -0,0 +1,85 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+// fakeRoot is a minimal Root used to satisfy NewPlan's "at least one root"
+// requirement in tests that don't exercise bundle execution itself.
+type fakeRoot struct {
+	id string
+}
+
+func (f *fakeRoot) ID() string                                                         { return f.id }
+func (f *fakeRoot) Up(ctx context.Context) error                                       { return nil }
+func (f *fakeRoot) Down(ctx context.Context) error                                     { return nil }
+func (f *fakeRoot) StartBundle(ctx context.Context, id string, data DataContext) error { return nil }
+func (f *fakeRoot) Process(ctx context.Context) error                                  { return nil }
+func (f *fakeRoot) FinishBundle(ctx context.Context) error                             { return nil }
+
+// fakeUnit is a plain Unit that is not a Root, for tests that need a unit
+// which can't drive a bundle.
+type fakeUnit struct {
+	id string
+}
+
+func (f *fakeUnit) ID() string                     { return f.id }
+func (f *fakeUnit) Up(ctx context.Context) error   { return nil }
+func (f *fakeUnit) Down(ctx context.Context) error { return nil }
+
+func TestNewPlan_MultipleDataSources(t *testing.T) {
+	s1 := &DataSource{SID: StreamID{Target: Target{ID: "source1", Name: "out1"}}}
+	s2 := &DataSource{SID: StreamID{Target: Target{ID: "source2", Name: "out2"}}}
+	root := &fakeRoot{id: "root"}
+
+	p, err := NewPlan("plan", []Unit{root, s1, s2})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+
+	got := p.SourcePTransformIDs()
+	sort.Strings(got)
+	want := []string{"source1", "source2"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("SourcePTransformIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestNewPlan_NoDataSources(t *testing.T) {
+	root := &fakeRoot{id: "root"}
+
+	p, err := NewPlan("plan", []Unit{root})
+	if err != nil {
+		t.Fatalf("NewPlan failed: %v", err)
+	}
+	if got := p.SourcePTransformIDs(); len(got) != 0 {
+		t.Errorf("SourcePTransformIDs() = %v, want empty", got)
+	}
+}
+
+func TestNewPlan_NoRoots(t *testing.T) {
+	// DataSource is itself a Root, so use a plain Unit here to exercise the
+	// "no root units" error path.
+	u := &fakeUnit{id: "not-a-root"}
+
+	if _, err := NewPlan("plan", []Unit{u}); err == nil {
+		t.Fatal("NewPlan with no roots succeeded, want error")
+	}
+}
@@ -0,0 +1,104 @@
+// Licensed to the Apache Software Foundation (ASF) under one or more
+// contributor license agreements.  See the NOTICE file distributed with
+// this work for additional information regarding copyright ownership.
+// The ASF licenses this file to You under the Apache License, Version 2.0
+// (the "License"); you may not use this file except in compliance with
+// the License.  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apache/beam/sdks/go/pkg/beam/internal/errors"
+)
+
+// Status is the execution status of a Plan or Unit.
+type Status int
+
+const (
+	// Initializing indicates the Plan/Unit has not yet been brought up.
+	Initializing Status = iota
+	// Active indicates the Plan/Unit is in the middle of processing a bundle.
+	Active
+	// Up indicates the Plan/Unit has been brought up and is ready for a bundle.
+	Up
+	// Broken indicates the Plan/Unit failed and cannot process further bundles.
+	Broken
+	// Down indicates the Plan/Unit has been taken down.
+	Down
+)
+
+func (v Status) String() string {
+	switch v {
+	case Initializing:
+		return "Initializing"
+	case Active:
+		return "Active"
+	case Up:
+		return "Up"
+	case Broken:
+		return "Broken"
+	case Down:
+		return "Down"
+	default:
+		return fmt.Sprintf("unknown status: %v", int(v))
+	}
+}
+
+// DataManager manages data channels to the runner for a single instruction.
+// It declares no methods, and DataSource does not use it.
+type DataManager interface {
+}
+
+// DataContext holds the per-bundle connections a Unit needs to service
+// data, state and timer requests from the runner. A fresh DataContext is
+// handed to Plan.Execute for every bundle.
+type DataContext struct {
+	Data DataManager
+}
+
+// Unit represents a node in an execution Plan.
+type Unit interface {
+	// ID returns a unique identifier for this unit, generally the
+	// originating PTransform ID.
+	ID() string
+	// Up is called once, when the owning Plan is first brought up.
+	Up(ctx context.Context) error
+	// Down is called once, when the owning Plan is taken down. Down is
+	// called even if the plan is broken, so it must tolerate a Unit that
+	// was never brought Up.
+	Down(ctx context.Context) error
+}
+
+// Root is a Unit that drives a bundle, such as a DataSource.
+type Root interface {
+	Unit
+
+	// StartBundle notifies the root that a new bundle has begun.
+	StartBundle(ctx context.Context, id string, data DataContext) error
+	// Process reads and processes the root's input until exhausted.
+	Process(ctx context.Context) error
+	// FinishBundle notifies the root that the active bundle has completed.
+	FinishBundle(ctx context.Context) error
+}
+
+// callNoPanic calls fn, converting any panic into an error so that a single
+// failing Unit cannot crash the worker.
+func callNoPanic(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errors.Errorf("panic: %v", p)
+		}
+	}()
+	return fn(ctx)
+}